@@ -0,0 +1,66 @@
+// Copyright (c) 2022 Bryan Frimin <bryan@frimin.fr>.
+//
+// Permission to use, copy, modify, and/or distribute this software for
+// any purpose with or without fee is hereby granted, provided that the
+// above copyright notice and this permission notice appear in all
+// copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL
+// WARRANTIES WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE
+// AUTHOR BE LIABLE FOR ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL
+// DAMAGES OR ANY DAMAGES WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR
+// PROFITS, WHETHER IN AN ACTION OF CONTRACT, NEGLIGENCE OR OTHER
+// TORTIOUS ACTION, ARISING OUT OF OR IN CONNECTION WITH THE USE OR
+// PERFORMANCE OF THIS SOFTWARE.
+
+// Package ar implements access to ar archives, the format produced by
+// the Unix ar(1) utility and used for static libraries (.a) and Debian
+// packages (.deb).
+package ar
+
+import (
+	"io/fs"
+	"time"
+)
+
+// MagicString is the byte sequence found at the beginning of every ar
+// archive.
+const MagicString = "!<arch>\n"
+
+// HeaderByteSize is the fixed size, in bytes, of a file header.
+const HeaderByteSize = 60
+
+// Header represents a single file header in an ar archive.
+type Header struct {
+	Name string
+	Date time.Time
+	Uid  int64
+	Gid  int64
+	Mode int64
+	Size int64
+}
+
+// ModTime returns the modification time carried by the header.
+func (h *Header) ModTime() time.Time {
+	return h.Date
+}
+
+// FileInfo returns an fs.FileInfo backed by the header.
+func (h *Header) FileInfo() fs.FileInfo {
+	return headerFileInfo{h}
+}
+
+// headerFileInfo implements fs.FileInfo for a Header. Since ar
+// archives have no notion of directory, every entry is a regular
+// file.
+type headerFileInfo struct {
+	h *Header
+}
+
+func (fi headerFileInfo) Name() string       { return fi.h.Name }
+func (fi headerFileInfo) Size() int64        { return fi.h.Size }
+func (fi headerFileInfo) Mode() fs.FileMode  { return fs.FileMode(fi.h.Mode).Perm() }
+func (fi headerFileInfo) ModTime() time.Time { return fi.h.Date }
+func (fi headerFileInfo) IsDir() bool        { return false }
+func (fi headerFileInfo) Sys() any           { return fi.h }
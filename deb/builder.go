@@ -0,0 +1,205 @@
+// Copyright (c) 2022 Bryan Frimin <bryan@frimin.fr>.
+//
+// Permission to use, copy, modify, and/or distribute this software for
+// any purpose with or without fee is hereby granted, provided that the
+// above copyright notice and this permission notice appear in all
+// copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL
+// WARRANTIES WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE
+// AUTHOR BE LIABLE FOR ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL
+// DAMAGES OR ANY DAMAGES WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR
+// PROFITS, WHETHER IN AN ACTION OF CONTRACT, NEGLIGENCE OR OTHER
+// TORTIOUS ACTION, ARISING OUT OF OR IN CONNECTION WITH THE USE OR
+// PERFORMANCE OF THIS SOFTWARE.
+
+package deb
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/gearnode/ar"
+)
+
+// Builder assembles a Debian binary package, hiding the ar container
+// format, the control and data tar archives, and their compression
+// from the caller.
+type Builder struct {
+	w       io.Writer
+	control Control
+	files   []builderFile
+}
+
+type builderFile struct {
+	header *tar.Header
+	data   []byte
+}
+
+// NewBuilder creates a new Builder writing a package described by
+// control to w.
+func NewBuilder(w io.Writer, control Control) *Builder {
+	return &Builder{w: w, control: control}
+}
+
+// AddFile adds a file to the package's data archive.
+func (b *Builder) AddFile(header *tar.Header, data []byte) {
+	b.files = append(b.files, builderFile{header: header, data: data})
+}
+
+// AddFS adds every regular file found in fsys to the package's data
+// archive, rooted at "/".
+func (b *Builder) AddFS(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return fmt.Errorf("cannot read %q: %w", path, err)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		b.AddFile(&tar.Header{
+			Name: "./" + path,
+			Mode: int64(info.Mode().Perm()),
+			Size: int64(len(data)),
+		}, data)
+
+		return nil
+	})
+}
+
+// Build assembles and writes the package: the debian-binary version
+// member, a control.tar.gz generated from the control fields and the
+// added files' md5sums, and a data.tar.gz holding the added files.
+func (b *Builder) Build() error {
+	w := ar.NewWriter(b.w)
+
+	if err := w.WriteMagicBytes(); err != nil {
+		return err
+	}
+
+	if err := writeMember(w, "debian-binary", []byte(SupportedVersion)); err != nil {
+		return fmt.Errorf("cannot write debian-binary: %w", err)
+	}
+
+	control, err := b.buildControlTarGz()
+	if err != nil {
+		return fmt.Errorf("cannot build control archive: %w", err)
+	}
+	if err := writeMember(w, "control.tar.gz", control); err != nil {
+		return fmt.Errorf("cannot write control archive: %w", err)
+	}
+
+	data, err := b.buildDataTarGz()
+	if err != nil {
+		return fmt.Errorf("cannot build data archive: %w", err)
+	}
+	if err := writeMember(w, "data.tar.gz", data); err != nil {
+		return fmt.Errorf("cannot write data archive: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("cannot close archive: %w", err)
+	}
+
+	return nil
+}
+
+func writeMember(w *ar.Writer, name string, data []byte) error {
+	if err := w.WriteHeader(&ar.Header{
+		Name: name,
+		Date: time.Unix(0, 0),
+		Mode: 0o100644,
+		Size: int64(len(data)),
+	}); err != nil {
+		return err
+	}
+
+	_, err := w.Write(data)
+	return err
+}
+
+func (b *Builder) buildControlTarGz() ([]byte, error) {
+	var md5sums strings.Builder
+	for _, f := range b.files {
+		sum := md5.Sum(f.data)
+		fmt.Fprintf(&md5sums, "%x  %s\n", sum, strings.TrimPrefix(f.header.Name, "./"))
+	}
+
+	var buf bytes.Buffer
+
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	if err := writeTarFile(tw, "control", b.control.encode()); err != nil {
+		return nil, err
+	}
+	if err := writeTarFile(tw, "md5sums", []byte(md5sums.String())); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (b *Builder) buildDataTarGz() ([]byte, error) {
+	var buf bytes.Buffer
+
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	for _, f := range b.files {
+		if err := tw.WriteHeader(f.header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name: name,
+		Mode: 0o644,
+		Size: int64(len(data)),
+	}); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(data)
+	return err
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2022 Bryan Frimin <bryan@frimin.fr>.
+//
+// Permission to use, copy, modify, and/or distribute this software for
+// any purpose with or without fee is hereby granted, provided that the
+// above copyright notice and this permission notice appear in all
+// copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL
+// WARRANTIES WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE
+// AUTHOR BE LIABLE FOR ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL
+// DAMAGES OR ANY DAMAGES WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR
+// PROFITS, WHETHER IN AN ACTION OF CONTRACT, NEGLIGENCE OR OTHER
+// TORTIOUS ACTION, ARISING OUT OF OR IN CONNECTION WITH THE USE OR
+// PERFORMANCE OF THIS SOFTWARE.
+
+package deb
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Control holds the fields of a Debian package control file.
+//
+// The most commonly used fields are exposed directly; any other field
+// found in the control file is preserved in Extra.
+type Control struct {
+	Package       string
+	Version       string
+	Architecture  string
+	Maintainer    string
+	Depends       string
+	InstalledSize string
+	Description   string
+	Extra         map[string]string
+}
+
+// parseControl looks for the "control" entry in tr and decodes it.
+func parseControl(tr *tar.Reader) (Control, error) {
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return Control{}, errors.New("missing control file")
+		}
+		if err != nil {
+			return Control{}, err
+		}
+
+		if strings.TrimPrefix(hdr.Name, "./") != "control" {
+			continue
+		}
+
+		return decodeControl(tr)
+	}
+}
+
+// decodeControl parses the RFC 822-like format of a Debian control
+// file: "Field: value" lines, with continuation lines indented by at
+// least one space or tab.
+func decodeControl(r io.Reader) (Control, error) {
+	fields := make(map[string]string)
+	var order []string
+
+	scanner := bufio.NewScanner(r)
+
+	var field string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		if line[0] == ' ' || line[0] == '\t' {
+			if field == "" {
+				return Control{}, errors.New("unexpected continuation line")
+			}
+
+			fields[field] += "\n" + strings.TrimPrefix(line, " ")
+			continue
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			return Control{}, fmt.Errorf("invalid control file line %q", line)
+		}
+
+		field = strings.TrimSpace(name)
+		fields[field] = strings.TrimSpace(value)
+		order = append(order, field)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Control{}, fmt.Errorf("cannot scan control file: %w", err)
+	}
+
+	c := Control{Extra: make(map[string]string)}
+
+	for _, name := range order {
+		value := fields[name]
+
+		switch name {
+		case "Package":
+			c.Package = value
+		case "Version":
+			c.Version = value
+		case "Architecture":
+			c.Architecture = value
+		case "Maintainer":
+			c.Maintainer = value
+		case "Depends":
+			c.Depends = value
+		case "Installed-Size":
+			c.InstalledSize = value
+		case "Description":
+			c.Description = value
+		default:
+			c.Extra[name] = value
+		}
+	}
+
+	return c, nil
+}
+
+// encode renders the control file representation of c.
+func (c Control) encode() []byte {
+	var buf bytes.Buffer
+
+	writeControlField(&buf, "Package", c.Package)
+	writeControlField(&buf, "Version", c.Version)
+	writeControlField(&buf, "Architecture", c.Architecture)
+	writeControlField(&buf, "Maintainer", c.Maintainer)
+	writeControlField(&buf, "Depends", c.Depends)
+	writeControlField(&buf, "Installed-Size", c.InstalledSize)
+	writeControlField(&buf, "Description", c.Description)
+
+	extraNames := make([]string, 0, len(c.Extra))
+	for name := range c.Extra {
+		extraNames = append(extraNames, name)
+	}
+	sort.Strings(extraNames)
+
+	for _, name := range extraNames {
+		writeControlField(&buf, name, c.Extra[name])
+	}
+
+	return buf.Bytes()
+}
+
+func writeControlField(buf *bytes.Buffer, name, value string) {
+	if value == "" {
+		return
+	}
+
+	fmt.Fprintf(buf, "%s: %s\n", name, value)
+}
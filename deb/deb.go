@@ -0,0 +1,177 @@
+// Copyright (c) 2022 Bryan Frimin <bryan@frimin.fr>.
+//
+// Permission to use, copy, modify, and/or distribute this software for
+// any purpose with or without fee is hereby granted, provided that the
+// above copyright notice and this permission notice appear in all
+// copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL
+// WARRANTIES WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE
+// AUTHOR BE LIABLE FOR ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL
+// DAMAGES OR ANY DAMAGES WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR
+// PROFITS, WHETHER IN AN ACTION OF CONTRACT, NEGLIGENCE OR OTHER
+// TORTIOUS ACTION, ARISING OUT OF OR IN CONNECTION WITH THE USE OR
+// PERFORMANCE OF THIS SOFTWARE.
+
+// Package deb implements reading and writing of Debian binary packages
+// (.deb files) on top of the ar package.
+//
+// A .deb file is an ar archive holding, in order, a "debian-binary"
+// version member, a compressed control archive (control.tar, possibly
+// gzip, xz or zstd compressed), and a compressed data archive with the
+// same set of possible compressions.
+package deb
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gearnode/ar"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// SupportedVersion is the only debian-binary version understood by
+// this package.
+const SupportedVersion = "2.0\n"
+
+// Package is a parsed Debian binary package.
+type Package struct {
+	// Control holds the fields found in the package's control file.
+	Control Control
+
+	data       *tar.Reader
+	dataCloser func() error
+}
+
+// Open reads the ar structure of a Debian package from r: it checks
+// the debian-binary version, decompresses and parses the control
+// member, and prepares the data member to be walked with Files.
+func Open(r io.Reader) (*Package, error) {
+	reader, err := ar.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read archive: %w", err)
+	}
+
+	var pkg Package
+
+	for {
+		hdr, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("missing data archive")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read archive: %w", err)
+		}
+
+		switch {
+		case hdr.Name == "debian-binary":
+			if err := checkVersion(reader, hdr.Size); err != nil {
+				return nil, err
+			}
+
+		case strings.HasPrefix(hdr.Name, "control.tar"):
+			tr, closer, err := decompressTar(hdr.Name, reader)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read control archive: %w", err)
+			}
+
+			pkg.Control, err = parseControl(tr)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse control file: %w", err)
+			}
+			if err := closer(); err != nil {
+				return nil, fmt.Errorf("cannot close control archive: %w", err)
+			}
+
+		case strings.HasPrefix(hdr.Name, "data.tar"):
+			// The data archive is left untouched so Files can stream
+			// it lazily instead of buffering its full, potentially
+			// large, content here. Its decompressor, if any, is
+			// closed by Package.Close.
+			tr, closer, err := decompressTar(hdr.Name, reader)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read data archive: %w", err)
+			}
+
+			pkg.data = tr
+			pkg.dataCloser = closer
+			return &pkg, nil
+
+		default:
+			return nil, fmt.Errorf("unknown package member %q", hdr.Name)
+		}
+	}
+}
+
+// Files returns a tar.Reader walking the entries of the package's data
+// archive, to be driven with the usual Next/Read loop.
+func (p *Package) Files() *tar.Reader {
+	return p.data
+}
+
+// Close releases the resources held by the data archive's
+// decompressor, such as the zstd.Decoder goroutines. Callers must call
+// Close once they are done with Files.
+func (p *Package) Close() error {
+	if p.dataCloser == nil {
+		return nil
+	}
+	return p.dataCloser()
+}
+
+func checkVersion(r io.Reader, size int64) error {
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return fmt.Errorf("cannot read debian-binary: %w", err)
+	}
+
+	if string(buf) != SupportedVersion {
+		return fmt.Errorf("unsupported debian-binary version %q", buf)
+	}
+
+	return nil
+}
+
+// decompressTar returns a tar.Reader over r, picking the decompressor
+// matching the compression extension of name, along with a closer
+// releasing any resources held by that decompressor. The closer is
+// never nil and is safe to call even when the format needs no
+// cleanup.
+func decompressTar(name string, r io.Reader) (*tar.Reader, func() error, error) {
+	noop := func() error { return nil }
+
+	switch {
+	case strings.HasSuffix(name, ".tar"):
+		return tar.NewReader(r), noop, nil
+
+	case strings.HasSuffix(name, ".tar.gz"):
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return tar.NewReader(gr), gr.Close, nil
+
+	case strings.HasSuffix(name, ".tar.xz"):
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return tar.NewReader(xr), noop, nil
+
+	case strings.HasSuffix(name, ".tar.zst"):
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return tar.NewReader(zr), func() error { zr.Close(); return nil }, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported archive member %q", name)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2022 Bryan Frimin <bryan@frimin.fr>.
+//
+// Permission to use, copy, modify, and/or distribute this software for
+// any purpose with or without fee is hereby granted, provided that the
+// above copyright notice and this permission notice appear in all
+// copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL
+// WARRANTIES WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE
+// AUTHOR BE LIABLE FOR ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL
+// DAMAGES OR ANY DAMAGES WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR
+// PROFITS, WHETHER IN AN ACTION OF CONTRACT, NEGLIGENCE OR OTHER
+// TORTIOUS ACTION, ARISING OUT OF OR IN CONNECTION WITH THE USE OR
+// PERFORMANCE OF THIS SOFTWARE.
+
+package deb
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f, err := os.Open("testdata/ar-testpkg.deb")
+	require.NoError(err)
+
+	pkg, err := Open(f)
+	require.NoError(err)
+	defer pkg.Close()
+
+	assert.Equal("ar-testpkg", pkg.Control.Package)
+	assert.Equal("1.0.0", pkg.Control.Version)
+	assert.Equal("amd64", pkg.Control.Architecture)
+	assert.Equal("libc6", pkg.Control.Depends)
+
+	var names []string
+	tr := pkg.Files()
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(err)
+		names = append(names, hdr.Name)
+	}
+
+	assert.Contains(names, "./usr/bin/hello")
+}
+
+func TestBuilder(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var buf bytes.Buffer
+
+	b := NewBuilder(&buf, Control{
+		Package:      "ar-testpkg",
+		Version:      "1.0.0",
+		Architecture: "amd64",
+		Maintainer:   "Test Suite <test@example.com>",
+	})
+	b.AddFile(&tar.Header{
+		Name: "./usr/bin/hello",
+		Mode: 0o755,
+		Size: 5,
+	}, []byte("hello"))
+
+	require.NoError(b.Build())
+
+	pkg, err := Open(&buf)
+	require.NoError(err)
+	defer pkg.Close()
+
+	assert.Equal("ar-testpkg", pkg.Control.Package)
+	assert.Equal("amd64", pkg.Control.Architecture)
+
+	hdr, err := pkg.Files().Next()
+	require.NoError(err)
+	assert.Equal("./usr/bin/hello", hdr.Name)
+}
@@ -0,0 +1,256 @@
+// Copyright (c) 2022 Bryan Frimin <bryan@frimin.fr>.
+//
+// Permission to use, copy, modify, and/or distribute this software for
+// any purpose with or without fee is hereby granted, provided that the
+// above copyright notice and this permission notice appear in all
+// copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL
+// WARRANTIES WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE
+// AUTHOR BE LIABLE FOR ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL
+// DAMAGES OR ANY DAMAGES WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR
+// PROFITS, WHETHER IN AN ACTION OF CONTRACT, NEGLIGENCE OR OTHER
+// TORTIOUS ACTION, ARISING OUT OF OR IN CONNECTION WITH THE USE OR
+// PERFORMANCE OF THIS SOFTWARE.
+
+package ar
+
+import (
+	"bytes"
+	"crypto"
+	_ "crypto/sha256" // register crypto.SHA256, the default hash
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Digester wraps a Reader or a Writer to compute reproducible,
+// content-addressable digests of the members that go through it.
+//
+// Each member's digest covers its stable header fields -- Name, Mode,
+// Uid, Gid and Size -- deliberately excluding Date, so two archives
+// holding the same files produce the same digests regardless of when
+// they were built. MemberDigest returns the digest of the member most
+// recently completed; ArchiveDigest, valid once every member has been
+// processed, is H(H(member1) || H(member2) || ...), which lets a
+// member be verified independently of its neighbours.
+//
+// A Reader-backed Digester must have each member fully read through
+// Digester.Read before Next is called again, or the skipped bytes will
+// not be accounted for in that member's digest.
+type Digester struct {
+	newHash func() hash.Hash
+
+	r *Reader
+	w *Writer
+
+	member     hash.Hash
+	lastDigest []byte
+	archive    hash.Hash
+}
+
+// NewDigester creates a Digester reading from r and computing SHA-256
+// digests.
+func NewDigester(r *Reader) *Digester {
+	return NewDigesterHash(r, crypto.SHA256)
+}
+
+// NewDigesterHash creates a Digester reading from r and computing h
+// digests.
+func NewDigesterHash(r *Reader, h crypto.Hash) *Digester {
+	return &Digester{newHash: h.New, r: r, archive: h.New()}
+}
+
+// NewWriterDigester creates a Digester writing to w and computing
+// SHA-256 digests.
+func NewWriterDigester(w *Writer) *Digester {
+	return NewWriterDigesterHash(w, crypto.SHA256)
+}
+
+// NewWriterDigesterHash creates a Digester writing to w and computing
+// h digests.
+func NewWriterDigesterHash(w *Writer, h crypto.Hash) *Digester {
+	return &Digester{newHash: h.New, w: w, archive: h.New()}
+}
+
+// Next advances to the next member, like Reader.Next, and starts
+// digesting it.
+func (d *Digester) Next() (*Header, error) {
+	if d.r == nil {
+		panic("ar: Next called on a Digester wrapping a Writer")
+	}
+
+	d.finishMember()
+
+	hdr, err := d.r.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	d.startMember(hdr)
+
+	return hdr, nil
+}
+
+// Read reads from the current member, like Reader.Read, feeding the
+// bytes read into its digest.
+func (d *Digester) Read(b []byte) (int, error) {
+	n, err := d.r.Read(b)
+	if n > 0 && d.member != nil {
+		d.member.Write(b[:n])
+	}
+
+	if errors.Is(err, io.EOF) {
+		d.finishMember()
+	}
+
+	return n, err
+}
+
+// WriteHeader writes header, like Writer.WriteHeader, and starts
+// digesting the member it introduces.
+func (d *Digester) WriteHeader(header *Header) error {
+	if d.w == nil {
+		panic("ar: WriteHeader called on a Digester wrapping a Reader")
+	}
+
+	d.finishMember()
+
+	if err := d.w.WriteHeader(header); err != nil {
+		return err
+	}
+
+	d.startMember(header)
+
+	return nil
+}
+
+// Write writes to the current member, like Writer.Write, feeding the
+// bytes written into its digest.
+func (d *Digester) Write(b []byte) (int, error) {
+	n, err := d.w.Write(b)
+	if n > 0 && d.member != nil {
+		d.member.Write(b[:n])
+	}
+
+	return n, err
+}
+
+// Close finishes digesting the last member processed and, if d wraps a
+// Writer, closes it.
+func (d *Digester) Close() error {
+	d.finishMember()
+
+	if d.w != nil {
+		return d.w.Close()
+	}
+
+	return nil
+}
+
+// MemberDigest returns the digest of the member most recently
+// completed. It returns nil before the first member has completed.
+func (d *Digester) MemberDigest() []byte {
+	return d.lastDigest
+}
+
+// ArchiveDigest returns H(H(member1) || H(member2) || ...) covering
+// every member processed so far.
+func (d *Digester) ArchiveDigest() []byte {
+	return d.archive.Sum(nil)
+}
+
+func (d *Digester) startMember(header *Header) {
+	d.member = d.newHash()
+	writeDigestHeader(d.member, header)
+
+	if header.Size == 0 {
+		d.finishMember()
+	}
+}
+
+func (d *Digester) finishMember() {
+	if d.member == nil {
+		return
+	}
+
+	sum := d.member.Sum(nil)
+	d.lastDigest = sum
+	d.archive.Write(sum)
+	d.member = nil
+}
+
+// writeDigestHeader feeds the canonical, reproducible encoding of a
+// header's stable fields into h.
+func writeDigestHeader(h hash.Hash, header *Header) {
+	fmt.Fprintf(h, "name:%s\nmode:%o\nuid:%d\ngid:%d\nsize:%d\n",
+		header.Name, header.Mode, header.Uid, header.Gid, header.Size)
+}
+
+// VerifyReader streams the ar archive read from r exactly once,
+// digesting every member, and reports the members whose name is found
+// in expected but whose digest does not match, as well as any name in
+// expected that is missing from the archive entirely. It is meant for
+// reproducible-build verification of .a and .deb files.
+func VerifyReader(r io.Reader, expected map[string][]byte) error {
+	reader, err := NewReader(r)
+	if err != nil {
+		return fmt.Errorf("cannot read archive: %w", err)
+	}
+
+	d := NewDigester(reader)
+
+	var mismatches []string
+	seen := make(map[string]bool, len(expected))
+
+	for {
+		hdr, err := d.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read archive: %w", err)
+		}
+
+		if _, err := io.Copy(io.Discard, d); err != nil {
+			return fmt.Errorf("cannot read member %q: %w", hdr.Name, err)
+		}
+
+		want, found := expected[hdr.Name]
+		if !found {
+			continue
+		}
+		seen[hdr.Name] = true
+
+		if !bytes.Equal(d.MemberDigest(), want) {
+			mismatches = append(mismatches, hdr.Name)
+		}
+	}
+
+	var missing []string
+	for name := range expected {
+		if !seen[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+
+	var problems []string
+	if len(mismatches) > 0 {
+		problems = append(problems, fmt.Sprintf("digest mismatch for member(s): %s",
+			strings.Join(mismatches, ", ")))
+	}
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("missing member(s): %s",
+			strings.Join(missing, ", ")))
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+
+	return nil
+}
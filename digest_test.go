@@ -0,0 +1,209 @@
+// Copyright (c) 2022 Bryan Frimin <bryan@frimin.fr>.
+//
+// Permission to use, copy, modify, and/or distribute this software for
+// any purpose with or without fee is hereby granted, provided that the
+// above copyright notice and this permission notice appear in all
+// copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL
+// WARRANTIES WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE
+// AUTHOR BE LIABLE FOR ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL
+// DAMAGES OR ANY DAMAGES WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR
+// PROFITS, WHETHER IN AN ACTION OF CONTRACT, NEGLIGENCE OR OTHER
+// TORTIOUS ACTION, ARISING OUT OF OR IN CONNECTION WITH THE USE OR
+// PERFORMANCE OF THIS SOFTWARE.
+
+package ar
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigesterReader(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f, err := os.Open("testdata/libfoo.a")
+	require.NoError(err)
+	defer f.Close()
+
+	r, err := NewReader(f)
+	require.NoError(err)
+
+	d := NewDigester(r)
+
+	digests := make(map[string][]byte)
+
+	for {
+		hdr, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(err)
+
+		_, err = io.Copy(io.Discard, d)
+		require.NoError(err)
+
+		digest := d.MemberDigest()
+		assert.Len(digest, 32)
+		digests[hdr.Name] = digest
+	}
+
+	assert.NotEmpty(digests)
+	assert.Len(d.ArchiveDigest(), 32)
+}
+
+func TestDigesterReaderDateIndependence(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	data := []byte("same content, different date")
+
+	build := func(date time.Time) []byte {
+		var buf bytes.Buffer
+
+		w := NewWriter(&buf)
+		require.NoError(w.WriteMagicBytes())
+		require.NoError(w.WriteHeader(&Header{
+			Name: "member",
+			Date: date,
+			Mode: 0100644,
+			Size: int64(len(data)),
+		}))
+		_, err := w.Write(data)
+		require.NoError(err)
+		require.NoError(w.Close())
+
+		return buf.Bytes()
+	}
+
+	digestOf := func(archive []byte) []byte {
+		r, err := NewReader(bytes.NewReader(archive))
+		require.NoError(err)
+
+		d := NewDigester(r)
+
+		_, err = d.Next()
+		require.NoError(err)
+
+		_, err = io.Copy(io.Discard, d)
+		require.NoError(err)
+
+		return d.MemberDigest()
+	}
+
+	a := digestOf(build(time.Unix(0, 0)))
+	b := digestOf(build(time.Unix(1700000000, 0)))
+
+	assert.Equal(a, b)
+}
+
+func TestDigesterWriter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf)
+	require.NoError(w.WriteMagicBytes())
+
+	d := NewWriterDigester(w)
+
+	require.NoError(d.WriteHeader(&Header{
+		Name: "member",
+		Date: time.Unix(0, 0),
+		Mode: 0100644,
+		Size: 5,
+	}))
+	_, err := d.Write([]byte("hello"))
+	require.NoError(err)
+	require.NoError(d.Close())
+
+	assert.Len(d.MemberDigest(), 32)
+	assert.Len(d.ArchiveDigest(), 32)
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(err)
+
+	rd := NewDigester(r)
+
+	_, err = rd.Next()
+	require.NoError(err)
+
+	_, err = io.Copy(io.Discard, rd)
+	require.NoError(err)
+
+	assert.Equal(d.MemberDigest(), rd.MemberDigest())
+}
+
+func TestVerifyReader(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f, err := os.Open("testdata/libfoo_bsd.a")
+	require.NoError(err)
+	defer f.Close()
+
+	r, err := NewReader(f)
+	require.NoError(err)
+
+	d := NewDigester(r)
+
+	expected := make(map[string][]byte)
+	for {
+		hdr, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(err)
+
+		_, err = io.Copy(io.Discard, d)
+		require.NoError(err)
+
+		expected[hdr.Name] = d.MemberDigest()
+	}
+
+	f2, err := os.Open("testdata/libfoo_bsd.a")
+	require.NoError(err)
+	defer f2.Close()
+
+	assert.NoError(VerifyReader(f2, expected))
+
+	f3, err := os.Open("testdata/libfoo_bsd.a")
+	require.NoError(err)
+	defer f3.Close()
+
+	tampered := make(map[string][]byte)
+	for name, digest := range expected {
+		tampered[name] = digest
+	}
+	for name := range tampered {
+		tampered[name] = append([]byte(nil), tampered[name]...)
+		tampered[name][0] ^= 0xff
+		break
+	}
+
+	assert.Error(VerifyReader(f3, tampered))
+
+	f4, err := os.Open("testdata/libfoo_bsd.a")
+	require.NoError(err)
+	defer f4.Close()
+
+	withMissing := make(map[string][]byte)
+	for name, digest := range expected {
+		withMissing[name] = digest
+	}
+	withMissing["does-not-exist.o"] = []byte("bogus")
+
+	err = VerifyReader(f4, withMissing)
+	require.Error(err)
+	assert.Contains(err.Error(), "does-not-exist.o")
+}
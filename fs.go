@@ -0,0 +1,248 @@
+// Copyright (c) 2022 Bryan Frimin <bryan@frimin.fr>.
+//
+// Permission to use, copy, modify, and/or distribute this software for
+// any purpose with or without fee is hereby granted, provided that the
+// above copyright notice and this permission notice appear in all
+// copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL
+// WARRANTIES WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE
+// AUTHOR BE LIABLE FOR ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL
+// DAMAGES OR ANY DAMAGES WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR
+// PROFITS, WHETHER IN AN ACTION OF CONTRACT, NEGLIGENCE OR OTHER
+// TORTIOUS ACTION, ARISING OUT OF OR IN CONNECTION WITH THE USE OR
+// PERFORMANCE OF THIS SOFTWARE.
+
+package ar
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// ReadCloser is a Reader backed by an io.ReaderAt, typically obtained
+// through OpenReader. Unlike Reader, it builds an index of the archive
+// members up front so they can be read back in any order, for example
+// through FS.
+//
+// The ReadCloser must be closed once the caller is done with it.
+type ReadCloser struct {
+	ra      io.ReaderAt
+	closer  io.Closer
+	entries []indexEntry
+	byName  map[string]int
+}
+
+type indexEntry struct {
+	header Header
+	offset int64
+}
+
+// OpenReader opens the named ar archive for random access.
+func OpenReader(name string) (*ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := newReadCloser(f, f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return rc, nil
+}
+
+func newReadCloser(ra io.ReaderAt, closer io.Closer) (*ReadCloser, error) {
+	sr := io.NewSectionReader(ra, 0, math.MaxInt64)
+
+	r, err := NewReader(sr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read archive: %w", err)
+	}
+
+	rc := &ReadCloser{
+		ra:     ra,
+		closer: closer,
+		byName: make(map[string]int),
+	}
+
+	for {
+		hdr, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read archive: %w", err)
+		}
+
+		offset, err := sr.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, fmt.Errorf("cannot locate member %q: %w",
+				hdr.Name, err)
+		}
+
+		rc.byName[hdr.Name] = len(rc.entries)
+		rc.entries = append(rc.entries, indexEntry{
+			header: *hdr,
+			offset: offset,
+		})
+	}
+
+	return rc, nil
+}
+
+// Close closes the underlying archive file.
+func (rc *ReadCloser) Close() error {
+	return rc.closer.Close()
+}
+
+func (rc *ReadCloser) open(name string) (*indexEntry, error) {
+	i, found := rc.byName[name]
+	if !found {
+		return nil, &fs.PathError{
+			Op: "open", Path: name, Err: fs.ErrNotExist,
+		}
+	}
+
+	return &rc.entries[i], nil
+}
+
+// FS returns a read-only fs.FS view of the archive, with each member
+// exposed as a file at the root of the file system.
+func (rc *ReadCloser) FS() fs.FS {
+	return &arFS{rc: rc}
+}
+
+type arFS struct {
+	rc *ReadCloser
+}
+
+func (afs *arFS) Open(name string) (fs.File, error) {
+	if name == "." {
+		return afs.newRootDir(), nil
+	}
+
+	e, err := afs.rc.open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &arFile{
+		header: e.header,
+		sr:     io.NewSectionReader(afs.rc.ra, e.offset, e.header.Size),
+	}, nil
+}
+
+func (afs *arFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." {
+		return nil, &fs.PathError{
+			Op: "readdir", Path: name, Err: fs.ErrNotExist,
+		}
+	}
+
+	return afs.dirEntries(), nil
+}
+
+func (afs *arFS) Stat(name string) (fs.FileInfo, error) {
+	if name == "." {
+		return arRootDirInfo{}, nil
+	}
+
+	e, err := afs.rc.open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.header.FileInfo(), nil
+}
+
+func (afs *arFS) ReadFile(name string) ([]byte, error) {
+	f, err := afs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(f.(io.Reader))
+}
+
+func (afs *arFS) dirEntries() []fs.DirEntry {
+	entries := make([]fs.DirEntry, len(afs.rc.entries))
+	for i, e := range afs.rc.entries {
+		entries[i] = fs.FileInfoToDirEntry(e.header.FileInfo())
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries
+}
+
+func (afs *arFS) newRootDir() *arRootDir {
+	return &arRootDir{entries: afs.dirEntries()}
+}
+
+// arFile implements fs.File for a single archive member.
+type arFile struct {
+	header Header
+	sr     *io.SectionReader
+}
+
+func (f *arFile) Stat() (fs.FileInfo, error) { return f.header.FileInfo(), nil }
+func (f *arFile) Read(b []byte) (int, error) { return f.sr.Read(b) }
+func (f *arFile) Close() error               { return nil }
+
+// arRootDir implements fs.ReadDirFile for the root of the archive,
+// which is the only directory in the file system.
+type arRootDir struct {
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *arRootDir) Stat() (fs.FileInfo, error) { return arRootDirInfo{}, nil }
+func (d *arRootDir) Close() error               { return nil }
+
+func (d *arRootDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: ".", Err: fs.ErrInvalid}
+}
+
+func (d *arRootDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries[d.offset:]
+		d.offset = len(d.entries)
+		return entries, nil
+	}
+
+	if d.offset >= len(d.entries) {
+		return nil, io.EOF
+	}
+
+	end := d.offset + n
+	if end > len(d.entries) {
+		end = len(d.entries)
+	}
+
+	entries := d.entries[d.offset:end]
+	d.offset = end
+
+	return entries, nil
+}
+
+// arRootDirInfo implements fs.FileInfo for the root directory.
+type arRootDirInfo struct{}
+
+func (arRootDirInfo) Name() string          { return "." }
+func (arRootDirInfo) Size() int64           { return 0 }
+func (arRootDirInfo) Mode() fs.FileMode     { return fs.ModeDir | 0555 }
+func (arRootDirInfo) ModTime() time.Time    { return time.Time{} }
+func (arRootDirInfo) IsDir() bool           { return true }
+func (arRootDirInfo) Sys() any              { return nil }
@@ -0,0 +1,60 @@
+// Copyright (c) 2022 Bryan Frimin <bryan@frimin.fr>.
+//
+// Permission to use, copy, modify, and/or distribute this software for
+// any purpose with or without fee is hereby granted, provided that the
+// above copyright notice and this permission notice appear in all
+// copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL
+// WARRANTIES WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE
+// AUTHOR BE LIABLE FOR ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL
+// DAMAGES OR ANY DAMAGES WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR
+// PROFITS, WHETHER IN AN ACTION OF CONTRACT, NEGLIGENCE OR OTHER
+// TORTIOUS ACTION, ARISING OUT OF OR IN CONNECTION WITH THE USE OR
+// PERFORMANCE OF THIS SOFTWARE.
+
+package ar
+
+import (
+	"io/fs"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenReaderFS(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	rc, err := OpenReader("testdata/libfoo.a")
+	require.NoError(err)
+	defer rc.Close()
+
+	fsys := rc.FS()
+
+	var names []string
+	err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		require.NoError(err)
+		if path != "." {
+			names = append(names, path)
+		}
+		return nil
+	})
+	require.NoError(err)
+
+	sort.Strings(names)
+	assert.Equal([]string{
+		"this_is_a_very_long_source_file_name_a.o",
+		"this_is_a_very_long_source_file_name_b.o",
+	}, names)
+
+	data, err := fs.ReadFile(fsys, "this_is_a_very_long_source_file_name_a.o")
+	require.NoError(err)
+	assert.Greater(len(data), 0)
+
+	_, err = fs.Stat(fsys, "does-not-exist")
+	assert.ErrorIs(err, fs.ErrNotExist)
+}
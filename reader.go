@@ -21,19 +21,38 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"iter"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// bsdNamePrefix is the name field prefix used by the BSD/Mac OS X
+// extension to store names longer than 16 bytes ahead of the file
+// data, as "#1/NN" where NN is the length of the name.
+const bsdNamePrefix = "#1/"
+
+// gnuNameTableName is the name of the special GNU/SysV member holding
+// the extended name string table. Other members reference an entry in
+// that table through a "/OFFSET" name.
+const gnuNameTableName = "//"
+
+// gnuSymbolTableName is the name of the special GNU/SysV member
+// holding the archive's symbol index, written by ar/ranlib for every
+// linkable .a archive. It carries no content of interest to callers
+// and is skipped transparently, like the "//" extended name table.
+const gnuSymbolTableName = "/"
+
 // Reader provides sequential access to the contents of a ar archive.
 //
 // Reader.Next advances to the next file in the archive (including the
 // first), and then Reader can be treated as an io.Reader to access the
 // file's data.
 type Reader struct {
-	io io.Reader
-	n  int64
-	p  int64
+	io    io.Reader
+	n     int64
+	p     int64
+	names map[int64]string // GNU extended name table, by offset
 }
 
 // NewReader creates a new Reader reading from r.
@@ -109,21 +128,131 @@ func (r *Reader) Next() (*Header, error) {
 		return nil, fmt.Errorf("cannot parse file size: %w", err)
 	}
 
+	name := readString(buf[0:16])
+
+	if name == gnuNameTableName {
+		if err := r.readGNUNameTable(fsize); err != nil {
+			return nil, err
+		}
+
+		return r.Next()
+	}
+
+	if name == gnuSymbolTableName {
+		if err := r.skipMember(fsize); err != nil {
+			return nil, err
+		}
+
+		return r.Next()
+	}
+
+	name, dataSize, err := r.resolveName(name, fsize)
+	if err != nil {
+		return nil, err
+	}
+
 	header := Header{
-		Name: readString(buf[0:16]),
+		Name: name,
 		Date: time.Unix(date, 0),
 		Uid:  uid,
 		Gid:  gid,
 		Mode: mode,
-		Size: fsize,
+		Size: dataSize,
 	}
 
-	r.n = header.Size
-	r.p = header.Size % 2
+	r.n = dataSize
+	r.p = fsize % 2
 
 	return &header, nil
 }
 
+// resolveName turns the raw 16-byte name field of a header into the
+// actual file name, transparently handling the BSD and GNU long name
+// extensions.
+//
+// It also returns the size of the file data itself, which for a BSD
+// extended name is smaller than the on-disk size since the name bytes
+// are stored ahead of the data but counted in it.
+func (r *Reader) resolveName(name string, size int64) (string, int64, error) {
+	switch {
+	case strings.HasPrefix(name, bsdNamePrefix):
+		n, err := strconv.ParseInt(name[len(bsdNamePrefix):], 10, 64)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid bsd file name length: %w", err)
+		}
+
+		nameBuf := make([]byte, n)
+		if _, err := io.ReadFull(r.io, nameBuf); err != nil {
+			return "", 0, fmt.Errorf("cannot read bsd file name: %w", err)
+		}
+
+		return string(nameBuf), size - n, nil
+
+	case strings.HasPrefix(name, "/") && name != "/" && name != gnuNameTableName:
+		offset, err := strconv.ParseInt(name[1:], 10, 64)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid gnu name table offset: %w", err)
+		}
+
+		resolved, found := r.names[offset]
+		if !found {
+			return "", 0, fmt.Errorf("unknown gnu name table offset %d", offset)
+		}
+
+		return resolved, size, nil
+
+	default:
+		// GNU ar terminates every short name, not just the ones
+		// routed through the "//" table, with a trailing "/" in the
+		// 16-byte field. A "/" can never be part of a legitimate
+		// POSIX filename, so stripping one is always correct,
+		// including for the common and BSD formats which never add
+		// it in the first place.
+		return strings.TrimSuffix(name, "/"), size, nil
+	}
+}
+
+// skipMember discards the size bytes, plus padding, of a member whose
+// content is of no interest to callers, such as the GNU symbol table.
+func (r *Reader) skipMember(size int64) error {
+	if _, err := io.CopyN(ioutil.Discard, r.io, size+size%2); err != nil {
+		return fmt.Errorf("cannot skip member: %w", err)
+	}
+
+	return nil
+}
+
+// readGNUNameTable reads the size bytes of a GNU "//" member and
+// populates the reader's extended name table.
+func (r *Reader) readGNUNameTable(size int64) error {
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r.io, buf); err != nil {
+		return fmt.Errorf("cannot read gnu name table: %w", err)
+	}
+
+	if _, err := io.CopyN(ioutil.Discard, r.io, size%2); err != nil {
+		return fmt.Errorf("cannot read gnu name table padding: %w", err)
+	}
+
+	names := make(map[int64]string)
+
+	var offset int64
+	for offset < int64(len(buf)) {
+		end := bytes.IndexByte(buf[offset:], '\n')
+		if end < 0 {
+			break
+		}
+
+		entry := strings.TrimSuffix(string(buf[offset:offset+int64(end)]), "/")
+		names[offset] = entry
+		offset += int64(end) + 1
+	}
+
+	r.names = names
+
+	return nil
+}
+
 // Read reads from the current file in the ar archive.
 //
 // It returns (0, io.EOF) when it reaches the end of that file, until
@@ -143,6 +272,29 @@ func (r *Reader) Read(b []byte) (int, error) {
 	return n, err
 }
 
+// All returns an iterator over the remaining files in the ar archive,
+// yielding each file's Header and an io.Reader for its data.
+//
+// The io.Reader yielded at a given iteration becomes invalid as soon as
+// the iteration continues, since it reads from the Reader itself.
+// Iteration stops, without error, at the end of the archive; a read
+// error aborts iteration early and is silently dropped, consistently
+// with other range-over-func iterators in the standard library.
+func (r *Reader) All() iter.Seq2[*Header, io.Reader] {
+	return func(yield func(*Header, io.Reader) bool) {
+		for {
+			hdr, err := r.Next()
+			if err != nil {
+				return
+			}
+
+			if !yield(hdr, r) {
+				return
+			}
+		}
+	}
+}
+
 func (r *Reader) skipUnread() error {
 	s := r.n + r.p
 	if _, err := io.CopyN(ioutil.Discard, r.io, s); err != nil {
@@ -169,6 +321,9 @@ func readInt(b []byte) (int64, error) {
 	for i > 0 && b[i] == ' ' {
 		i--
 	}
+	if i == 0 && b[0] == ' ' {
+		return 0, nil
+	}
 
 	n, err := strconv.ParseInt(string(b[0:i+1]), 10, 64)
 	return n, err
@@ -179,6 +334,9 @@ func readOctal(b []byte) (int64, error) {
 	for i > 0 && b[i] == ' ' {
 		i--
 	}
+	if i == 0 && b[0] == ' ' {
+		return 0, nil
+	}
 
 	n, err := strconv.ParseInt(string(b[0:i+1]), 8, 64)
 	return n, err
@@ -80,6 +80,145 @@ func TestReadHeader(t *testing.T) {
 	assert.ErrorIs(err, io.EOF)
 }
 
+func TestReadHeaderGNUExtendedNames(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f, err := os.Open("testdata/libfoo.a")
+	require.NoError(err)
+
+	r, err := NewReader(f)
+	require.NoError(err)
+
+	hdr, err := r.Next()
+	require.NoError(err)
+	assert.Equal("this_is_a_very_long_source_file_name_a.o", hdr.Name)
+
+	hdr, err = r.Next()
+	require.NoError(err)
+	assert.Equal("this_is_a_very_long_source_file_name_b.o", hdr.Name)
+
+	_, err = r.Next()
+	assert.ErrorIs(err, io.EOF)
+}
+
+// TestReadHeaderGNUMixedNames reads a real archive produced by GNU
+// binutils' ar (ar rcs), mixing a symbol table, a string table and
+// both short and long member names, to make sure the trailing "/"
+// GNU ar appends to every short name -- not just the ones routed
+// through the "//" table -- is stripped, and that the "/" symbol
+// table member is skipped rather than surfaced to the caller.
+func TestReadHeaderGNUMixedNames(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f, err := os.Open("testdata/libfoo2.a")
+	require.NoError(err)
+
+	r, err := NewReader(f)
+	require.NoError(err)
+
+	hdr, err := r.Next()
+	require.NoError(err)
+	assert.Equal("a.o", hdr.Name)
+
+	hdr, err = r.Next()
+	require.NoError(err)
+	assert.Equal("b.o", hdr.Name)
+
+	hdr, err = r.Next()
+	require.NoError(err)
+	assert.Equal("this_is_a_very_long_source_file_name_c.o", hdr.Name)
+
+	_, err = r.Next()
+	assert.ErrorIs(err, io.EOF)
+}
+
+func TestReadHeaderGNULongFileNames(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f, err := os.Open("testdata/longnames.deb")
+	require.NoError(err)
+
+	r, err := NewReader(f)
+	require.NoError(err)
+
+	hdr, err := r.Next()
+	assert.NoError(err)
+	assert.Equal("debian-binary", hdr.Name)
+
+	hdr, err = r.Next()
+	assert.NoError(err)
+	assert.Equal("this_is_a_very_long_control_tar_member_name.tar.gz",
+		hdr.Name)
+	assert.Equal(int64(25), hdr.Size)
+
+	data := make([]byte, hdr.Size)
+	_, err = io.ReadFull(r, data)
+	assert.NoError(err)
+	assert.Equal("fake control tar payload\n", string(data))
+
+	_, err = r.Next()
+	assert.ErrorIs(err, io.EOF)
+}
+
+func TestReadHeaderBSDExtendedNames(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f, err := os.Open("testdata/libfoo_bsd.a")
+	require.NoError(err)
+
+	r, err := NewReader(f)
+	require.NoError(err)
+
+	hdr, err := r.Next()
+	assert.NoError(err)
+	assert.Equal("short.o", hdr.Name)
+	assert.Equal(int64(21), hdr.Size)
+
+	data := make([]byte, hdr.Size)
+	_, err = io.ReadFull(r, data)
+	assert.NoError(err)
+	assert.Equal("short member payload\n", string(data))
+
+	hdr, err = r.Next()
+	assert.NoError(err)
+	assert.Equal("this_is_a_very_long_bsd_member_name.o", hdr.Name)
+	assert.Equal(int64(25), hdr.Size)
+
+	data = make([]byte, hdr.Size)
+	_, err = io.ReadFull(r, data)
+	assert.NoError(err)
+	assert.Equal("long bsd member payload\n", string(data))
+
+	_, err = r.Next()
+	assert.ErrorIs(err, io.EOF)
+}
+
+func TestReaderAll(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	f, err := os.Open("testdata/apt_2.4.5_amd64.deb")
+	require.NoError(err)
+
+	r, err := NewReader(f)
+	require.NoError(err)
+
+	var names []string
+	for hdr, body := range r.All() {
+		data, err := io.ReadAll(body)
+		require.NoError(err)
+		assert.Equal(hdr.Size, int64(len(data)))
+
+		names = append(names, hdr.Name)
+	}
+
+	assert.Equal([]string{"debian-binary", "control.tar.xz", "data.tar.xz"}, names)
+}
+
 func TestRead(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -17,8 +17,11 @@
 package ar
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"os"
 	"strconv"
 )
 
@@ -26,23 +29,88 @@ var (
 	ErrWriteTooLong = errors.New("write too long")
 )
 
+// memberBufferThreshold is the maximum number of bytes WriteFile
+// buffers in memory, for a member whose size it has to compute itself,
+// before spilling the rest to a temporary file.
+const memberBufferThreshold = 1 << 20 // 1 MiB
+
+// WriterFormat selects how a Writer encodes file names that do not fit
+// in the standard 16-byte name field.
+type WriterFormat int
+
+const (
+	// FormatCommon is the lowest common denominator format: names
+	// longer than 16 bytes are simply truncated by the fixed-size
+	// name field. It is the historical behaviour of this package.
+	FormatCommon WriterFormat = iota
+
+	// FormatBSD encodes names longer than 16 bytes using the BSD/Mac
+	// OS X extension: the name field holds "#1/NN" and the NN-byte
+	// name is written ahead of the file data.
+	FormatBSD
+
+	// FormatGNU encodes names longer than 16 bytes using the
+	// GNU/SysV extension: they are stored in a "//" string table
+	// member written at the beginning of the archive, and the
+	// members themselves reference an entry of that table through a
+	// "/OFFSET" name.
+	FormatGNU
+)
+
 // Writer provides sequential writing of a ar archive.
 //
 // Write.WriteMagicBytes begins a new file, then WriteHeader begins a
 // new file with the provided Header, and then Writer can be treated as
-// an io.Writer to supply that file's data.
+// an io.Writer to supply that file's data. Close must be called once
+// every member has been written, to flush the padding byte of a
+// trailing odd-sized member and, in FormatGNU, the archive itself.
 type Writer struct {
-	io io.Writer
-	n  int64
+	io     io.Writer
+	format WriterFormat
+	n      int64 // bytes still expected for the current member
+	pad    bool  // pending single '\n' padding byte
+
+	// FormatGNU needs every member to be buffered, since the "//"
+	// string table, which must be written first, depends on the
+	// names of all of them.
+	gnuTable   bytes.Buffer
+	gnuOffsets map[string]int64
+	gnuMembers []gnuMember
+	gnuName    string
+	gnuHeader  Header
+	gnuData    bytes.Buffer
+}
+
+// gnuMember is a member buffered while writing a FormatGNU archive,
+// ready to be written to the underlying writer once the "//" string
+// table has been completed.
+type gnuMember struct {
+	name   string
+	header Header
+	data   []byte
 }
 
-// NewWriter creates a new Writer writing to w.
+// NewWriter creates a new Writer writing to w using FormatCommon.
 func NewWriter(w io.Writer) *Writer {
 	return &Writer{io: w}
 }
 
+// NewWriterFormat creates a new Writer writing to w using the given
+// format.
+func NewWriterFormat(w io.Writer, format WriterFormat) *Writer {
+	return &Writer{io: w, format: format, gnuOffsets: make(map[string]int64)}
+}
+
 // WriteMagicBytes writes ar magic bytes header.
+//
+// In FormatGNU, the magic bytes are actually written by Close once
+// every member is known, so this is a no-op; callers should still call
+// it so the same code works for every format.
 func (w *Writer) WriteMagicBytes() error {
+	if w.format == FormatGNU {
+		return nil
+	}
+
 	_, err := w.io.Write([]byte(MagicString))
 	return err
 }
@@ -53,42 +121,303 @@ func (w *Writer) WriteMagicBytes() error {
 // file. If the current file is not fully written, then this returns an
 // error.
 func (w *Writer) WriteHeader(header *Header) error {
-	buf := make([]byte, HeaderByteSize)
+	if header.Size < 0 {
+		return fmt.Errorf("invalid negative header size")
+	}
 
-	writeString(buf[0:16], header.Name)
-	writeInt(buf[16:28], header.Date.Unix())
-	writeInt(buf[28:34], header.Uid)
-	writeInt(buf[34:40], header.Gid)
-	writeOctal(buf[40:48], header.Mode)
-	writeInt(buf[48:58], header.Size)
-	writeString(buf[58:60], "`\n")
+	switch w.format {
+	case FormatBSD:
+		return w.writeHeaderBSD(header)
+	case FormatGNU:
+		return w.writeHeaderGNU(header)
+	default:
+		return w.writeHeaderCommon(header.Name, header)
+	}
+}
+
+func (w *Writer) writeHeaderCommon(name string, header *Header) error {
+	if w.n != 0 {
+		return fmt.Errorf("previous member not fully written")
+	}
+
+	if err := w.flushPad(); err != nil {
+		return err
+	}
+
+	buf := encodeHeader(name, header)
 
-	_, err := w.io.Write(buf)
+	if _, err := w.io.Write(buf); err != nil {
+		return err
+	}
+
+	w.n = header.Size
+	w.pad = header.Size%2 == 1
+
+	return nil
+}
+
+// flushPad writes the pending padding byte of the previously written
+// member, if any.
+func (w *Writer) flushPad() error {
+	if !w.pad {
+		return nil
+	}
+
+	if _, err := w.io.Write([]byte("\n")); err != nil {
+		return err
+	}
+
+	w.pad = false
+
+	return nil
+}
+
+func (w *Writer) writeHeaderBSD(header *Header) error {
+	if len(header.Name) <= 16 {
+		return w.writeHeaderCommon(header.Name, header)
+	}
+
+	encoded := *header
+	encoded.Size = header.Size + int64(len(header.Name))
+
+	name := fmt.Sprintf("%s%d", bsdNamePrefix, len(header.Name))
+	if err := w.writeHeaderCommon(name, &encoded); err != nil {
+		return err
+	}
+
+	n, err := w.Write([]byte(header.Name))
 	if err != nil {
 		return err
 	}
+	if n != len(header.Name) {
+		return fmt.Errorf("cannot write bsd file name")
+	}
 
+	return nil
+}
+
+func (w *Writer) writeHeaderGNU(header *Header) error {
+	if w.n != 0 {
+		return fmt.Errorf("previous member not fully written")
+	}
+
+	if header.Name == "" {
+		return fmt.Errorf("empty member name")
+	}
+
+	w.flushGNUMember()
+
+	name := header.Name
+	if len(name) > 15 {
+		// Longer than the 16-byte field can hold once the mandatory
+		// trailing "/" is appended: route it through the "//" string
+		// table instead.
+		offset, found := w.gnuOffsets[name]
+		if !found {
+			offset = int64(w.gnuTable.Len())
+			w.gnuTable.WriteString(name)
+			w.gnuTable.WriteString("/\n")
+			w.gnuOffsets[name] = offset
+		}
+
+		name = fmt.Sprintf("/%d", offset)
+	} else {
+		// Real GNU ar terminates every short name with a trailing
+		// "/" in the name field too, not just long-table entries.
+		name += "/"
+	}
+
+	w.gnuName = name
+	w.gnuHeader = *header
 	w.n = header.Size
+
 	return nil
 }
 
+func (w *Writer) flushGNUMember() {
+	if w.gnuName == "" {
+		return
+	}
+
+	w.gnuMembers = append(w.gnuMembers, gnuMember{
+		name:   w.gnuName,
+		header: w.gnuHeader,
+		data:   append([]byte(nil), w.gnuData.Bytes()...),
+	})
+
+	w.gnuName = ""
+	w.gnuData.Reset()
+}
+
 // Write writes to the current file in the ar archive.
 //
 // Write returns the error ErrWriteTooLong if more than Header.Size
-// bytes are written after WriteHeader.
+// bytes are written after WriteHeader. It never mutates b; the single
+// padding byte required by odd-sized members is instead written lazily,
+// by the next WriteHeader call or by Close.
 func (w *Writer) Write(b []byte) (int, error) {
 	if int64(len(b)) > w.n {
 		return 0, ErrWriteTooLong
 	}
 
-	if len(b)%2 == 1 {
-		b = append(b, '\n')
+	if w.format == FormatGNU {
+		n, err := w.gnuData.Write(b)
+		w.n -= int64(n)
+		return n, err
 	}
 
 	n, err := w.io.Write(b)
+	w.n -= int64(n)
+
 	return n, err
 }
 
+// WriteFile writes hdr then streams all of r as the member's data.
+//
+// If hdr.Size is -1, WriteFile buffers r entirely first, to compute its
+// size, spilling to a temporary file past memberBufferThreshold bytes;
+// this lets callers stream from a source, such as an *os.File, whose
+// size they do not know or trust in advance. Otherwise, it streams
+// directly, as io.Copy would.
+func (w *Writer) WriteFile(hdr *Header, r io.Reader) (int64, error) {
+	if hdr.Size != -1 {
+		if err := w.WriteHeader(hdr); err != nil {
+			return 0, err
+		}
+
+		return io.Copy(w, r)
+	}
+
+	buffered, size, cleanup, err := bufferMember(r)
+	if err != nil {
+		return 0, fmt.Errorf("cannot buffer member: %w", err)
+	}
+	defer cleanup()
+
+	sized := *hdr
+	sized.Size = size
+
+	if err := w.WriteHeader(&sized); err != nil {
+		return 0, err
+	}
+
+	return io.Copy(w, buffered)
+}
+
+// bufferMember buffers r, spilling to a temporary file past
+// memberBufferThreshold bytes, and returns a reader over the buffered
+// data together with its total size. The returned cleanup function
+// must be called once the reader is no longer needed.
+func bufferMember(r io.Reader) (io.Reader, int64, func(), error) {
+	noop := func() {}
+
+	var buf bytes.Buffer
+
+	n, err := io.CopyN(&buf, r, memberBufferThreshold)
+	if errors.Is(err, io.EOF) {
+		return bytes.NewReader(buf.Bytes()), n, noop, nil
+	}
+	if err != nil {
+		return nil, 0, noop, err
+	}
+
+	f, err := os.CreateTemp("", "ar-member-*")
+	if err != nil {
+		return nil, 0, noop, err
+	}
+
+	cleanup := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	if _, err := io.Copy(f, &buf); err != nil {
+		cleanup()
+		return nil, 0, noop, err
+	}
+
+	rest, err := io.Copy(f, r)
+	if err != nil {
+		cleanup()
+		return nil, 0, noop, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, 0, noop, err
+	}
+
+	return f, n + rest, cleanup, nil
+}
+
+// Close finishes writing the archive.
+//
+// In FormatGNU, the archive is entirely buffered until Close, since the
+// "//" string table must be written before the members that reference
+// it. In other formats, every member is written to the underlying
+// writer as soon as it is provided, but Close must still be called to
+// flush the padding byte of a trailing odd-sized member.
+func (w *Writer) Close() error {
+	if w.format != FormatGNU {
+		return w.flushPad()
+	}
+
+	w.flushGNUMember()
+
+	if _, err := w.io.Write([]byte(MagicString)); err != nil {
+		return err
+	}
+
+	table := w.gnuTable.Bytes()
+	if err := w.writeGNURawMember(gnuNameTableName, &Header{Size: int64(len(table))}, table); err != nil {
+		return err
+	}
+
+	for _, m := range w.gnuMembers {
+		if err := w.writeGNURawMember(m.name, &m.header, m.data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeGNURawMember writes one member directly to the underlying
+// writer: unlike writeHeaderCommon and Write, it bypasses the pad/n
+// bookkeeping entirely, since every FormatGNU member is already fully
+// buffered by the time Close writes it out.
+func (w *Writer) writeGNURawMember(name string, header *Header, data []byte) error {
+	if _, err := w.io.Write(encodeHeader(name, header)); err != nil {
+		return err
+	}
+
+	if _, err := w.io.Write(data); err != nil {
+		return err
+	}
+
+	if len(data)%2 == 1 {
+		if _, err := w.io.Write([]byte("\n")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encodeHeader(name string, header *Header) []byte {
+	buf := make([]byte, HeaderByteSize)
+
+	writeString(buf[0:16], name)
+	writeInt(buf[16:28], header.Date.Unix())
+	writeInt(buf[28:34], header.Uid)
+	writeInt(buf[34:40], header.Gid)
+	writeOctal(buf[40:48], header.Mode)
+	writeInt(buf[48:58], header.Size)
+	writeString(buf[58:60], "`\n")
+
+	return buf
+}
+
 func writeString(b []byte, s string) {
 	for len(s) < len(b) {
 		s = s + " "
@@ -0,0 +1,212 @@
+// Copyright (c) 2022 Bryan Frimin <bryan@frimin.fr>.>
+//
+// Permission to use, copy, modify, and/or distribute this software for
+// any purpose with or without fee is hereby granted, provided that the
+// above copyright notice and this permission notice appear in all
+// copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL
+// WARRANTIES WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED
+// WARRANTIES OF MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE
+// AUTHOR BE LIABLE FOR ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL
+// DAMAGES OR ANY DAMAGES WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR
+// PROFITS, WHETHER IN AN ACTION OF CONTRACT, NEGLIGENCE OR OTHER
+// TORTIOUS ACTION, ARISING OUT OF OR IN CONNECTION WITH THE USE OR
+// PERFORMANCE OF THIS SOFTWARE.
+
+package ar
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterOddSizePadding(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf)
+	require.NoError(w.WriteMagicBytes())
+
+	require.NoError(w.WriteHeader(&Header{
+		Name: "odd.txt",
+		Date: time.Unix(0, 0),
+		Mode: 0100644,
+		Size: 3,
+	}))
+	n, err := w.Write([]byte("abc"))
+	require.NoError(err)
+	assert.Equal(3, n)
+	require.NoError(w.Close())
+
+	assert.Equal(byte('\n'), buf.Bytes()[buf.Len()-1])
+	assert.Equal("abc", string(buf.Bytes()[buf.Len()-4:buf.Len()-1]))
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(err)
+
+	hdr, err := r.Next()
+	assert.NoError(err)
+	assert.Equal(int64(3), hdr.Size)
+
+	got := make([]byte, hdr.Size)
+	_, err = r.Read(got)
+	assert.NoError(err)
+	assert.Equal("abc", string(got))
+
+	_, err = r.Next()
+	assert.ErrorIs(err, io.EOF)
+}
+
+func TestWriterWriteTooLong(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf)
+	require.NoError(w.WriteMagicBytes())
+	require.NoError(w.WriteHeader(&Header{Name: "f", Size: 2}))
+
+	_, err := w.Write([]byte("ab"))
+	require.NoError(err)
+
+	_, err = w.Write([]byte("c"))
+	assert.ErrorIs(err, ErrWriteTooLong)
+}
+
+func TestWriterWriteFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf)
+	require.NoError(w.WriteMagicBytes())
+
+	data := "some file content streamed without a known size\n"
+	n, err := w.WriteFile(&Header{
+		Name: "streamed",
+		Date: time.Unix(0, 0),
+		Mode: 0100644,
+		Size: -1,
+	}, strings.NewReader(data))
+	require.NoError(err)
+	assert.Equal(int64(len(data)), n)
+	require.NoError(w.Close())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(err)
+
+	hdr, err := r.Next()
+	assert.NoError(err)
+	assert.Equal("streamed", hdr.Name)
+	assert.Equal(int64(len(data)), hdr.Size)
+
+	got := make([]byte, hdr.Size)
+	_, err = r.Read(got)
+	assert.NoError(err)
+	assert.Equal(data, string(got))
+}
+
+func TestWriterFormatBSD(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var buf bytes.Buffer
+
+	w := NewWriterFormat(&buf, FormatBSD)
+	require.NoError(w.WriteMagicBytes())
+
+	name := "this_is_a_very_long_bsd_member_name.o"
+	data := []byte("long bsd member payload\n")
+
+	require.NoError(w.WriteHeader(&Header{
+		Name: name,
+		Date: time.Unix(0, 0),
+		Mode: 0100644,
+		Size: int64(len(data)),
+	}))
+	_, err := w.Write(data)
+	require.NoError(err)
+	require.NoError(w.Close())
+
+	r, err := NewReader(&buf)
+	require.NoError(err)
+
+	hdr, err := r.Next()
+	assert.NoError(err)
+	assert.Equal(name, hdr.Name)
+	assert.Equal(int64(len(data)), hdr.Size)
+
+	got := make([]byte, hdr.Size)
+	_, err = r.Read(got)
+	assert.NoError(err)
+	assert.Equal(data, got)
+}
+
+func TestWriterFormatGNU(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var buf bytes.Buffer
+
+	w := NewWriterFormat(&buf, FormatGNU)
+	require.NoError(w.WriteMagicBytes())
+
+	names := []string{
+		"short.o",
+		"this_is_a_very_long_gnu_member_name_one.o",
+		"this_is_a_very_long_gnu_member_name_two.o",
+	}
+
+	for _, name := range names {
+		data := []byte("payload of " + name)
+
+		require.NoError(w.WriteHeader(&Header{
+			Name: name,
+			Date: time.Unix(0, 0),
+			Mode: 0100644,
+			Size: int64(len(data)),
+		}))
+		_, err := w.Write(data)
+		require.NoError(err)
+	}
+
+	require.NoError(w.Close())
+
+	r, err := NewReader(&buf)
+	require.NoError(err)
+
+	for _, name := range names {
+		hdr, err := r.Next()
+		assert.NoError(err)
+		assert.Equal(name, hdr.Name)
+
+		got := make([]byte, hdr.Size)
+		_, err = r.Read(got)
+		assert.NoError(err)
+		assert.Equal("payload of "+name, string(got))
+	}
+}
+
+func TestWriterFormatGNUEmptyName(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var buf bytes.Buffer
+
+	w := NewWriterFormat(&buf, FormatGNU)
+	require.NoError(w.WriteMagicBytes())
+
+	err := w.WriteHeader(&Header{Size: 0})
+	assert.Error(err)
+}